@@ -0,0 +1,39 @@
+package annoy
+
+// AllowSet is a bitmap-backed set of item ids, for use as the predicate
+// argument to GetNnsByItemFiltered / GetNnsByVectorFiltered. It is the
+// simplest case of the label-restricted search pattern used by vector
+// search engines like Bleve or Dgraph: build one AllowSet per label/tag
+// and pass its Contains method (or the set itself, via Func) as the
+// filter so only matching items are considered during traversal.
+type AllowSet struct {
+	bits []uint64
+}
+
+// NewAllowSet returns an empty AllowSet sized to hold ids up to nItems-1
+// without reallocating.
+func NewAllowSet(nItems int32) *AllowSet {
+	return &AllowSet{bits: make([]uint64, (nItems+63)/64)}
+}
+
+func (s *AllowSet) Add(i int32) {
+	word := int(i / 64)
+	for word >= len(s.bits) {
+		s.bits = append(s.bits, 0)
+	}
+	s.bits[word] |= 1 << uint(i%64)
+}
+
+func (s *AllowSet) Contains(i int32) bool {
+	word := int(i / 64)
+	if word < 0 || word >= len(s.bits) {
+		return false
+	}
+	return s.bits[word]&(1<<uint(i%64)) != 0
+}
+
+// Func returns the AllowSet as a predicate suitable for the Filtered search
+// methods.
+func (s *AllowSet) Func() func(int32) bool {
+	return s.Contains
+}
@@ -6,6 +6,7 @@ import (
 	"math"
 	"os"
 	"sort"
+	"sync"
 
 	"github.com/edsrzf/mmap-go"
 )
@@ -32,7 +33,12 @@ type AnnoyIndex[D DistanceMetric] struct {
 	k        int32
 	fd       *os.File
 	mmap     mmap.MMap
-	cache    map[int32]*Node
+	// cache holds decoded nodes when the index was loaded fully into
+	// memory (mmap mode bypasses it, reading straight from index.nodes
+	// instead). sync.Map makes it safe for the concurrent readers used by
+	// GetNnsByVectorBatch/GetNnsByItemBatch.
+	cache sync.Map
+	pool  sync.Pool
 }
 
 func NewAnnoyIndex[D DistanceMetric](f int) *AnnoyIndex[D] {
@@ -42,16 +48,45 @@ func NewAnnoyIndex[D DistanceMetric](f int) *AnnoyIndex[D] {
 		nItems: 0,
 		nNodes: 0,
 		roots:  []int32{},
-		cache:  make(map[int32]*Node),
 	}
 
-	index.s = 12 + f*4
-	index.k = int32((index.s - 4) / 4)
+	index.s = 12 + f*4 + index.distance.ExtraSize()
+	index.k = int32(f + 2)
+	index.pool.New = func() any {
+		return &queryScratch{
+			vNode: &Node{V: make([]float32, f)},
+			pq:    &PriorityQueue{},
+			nnSet: make(map[int32]struct{}),
+		}
+	}
 	index.reinitialize()
 
 	return index
 }
 
+func NewAnnoyIndexAngular(f int) *AnnoyIndex[Angular] {
+	return NewAnnoyIndex[Angular](f)
+}
+
+func NewAnnoyIndexEuclidean(f int) *AnnoyIndex[Euclidean] {
+	return NewAnnoyIndex[Euclidean](f)
+}
+
+func NewAnnoyIndexManhattan(f int) *AnnoyIndex[Manhattan] {
+	return NewAnnoyIndex[Manhattan](f)
+}
+
+func NewAnnoyIndexDotProduct(f int) *AnnoyIndex[DotProduct] {
+	return NewAnnoyIndex[DotProduct](f)
+}
+
+// NewAnnoyIndexHamming builds a Hamming index where f is the number of
+// 32-bit words used to pack bits (32*f total bits per item), matching
+// Hamming.Distance's interpretation of f.
+func NewAnnoyIndexHamming(f int) *AnnoyIndex[Hamming] {
+	return NewAnnoyIndex[Hamming](f)
+}
+
 func (index *AnnoyIndex[D]) reinitialize() {
 	index.fd = nil
 	index.nodes = nil
@@ -144,11 +179,26 @@ func (index *AnnoyIndex[D]) GetDistance(i, j int32) float32 {
 
 func (index *AnnoyIndex[D]) GetNnsByItem(item int32, n, searchK int) ([]int32, []float32) {
 	m := index.getNode(item)
-	return index.getAllNns(m.V[:], n, searchK)
+	return index.getAllNns(m.V[:], n, searchK, nil)
 }
 
 func (index *AnnoyIndex[D]) GetNnsByVector(v []float32, n, searchK int) ([]int32, []float32) {
-	return index.getAllNns(v, n, searchK)
+	return index.getAllNns(v, n, searchK, nil)
+}
+
+// GetNnsByItemFiltered is like GetNnsByItem, but only considers items
+// accepted by allow. searchK is interpreted as the number of accepted
+// candidates to gather before truncating to n, so filtering never returns
+// fewer than n results just because earlier candidates were rejected.
+func (index *AnnoyIndex[D]) GetNnsByItemFiltered(item int32, n, searchK int, allow func(int32) bool) ([]int32, []float32) {
+	m := index.getNode(item)
+	return index.getAllNns(m.V[:], n, searchK, allow)
+}
+
+// GetNnsByVectorFiltered is like GetNnsByVector, but only considers items
+// accepted by allow. See GetNnsByItemFiltered for how searchK is interpreted.
+func (index *AnnoyIndex[D]) GetNnsByVectorFiltered(v []float32, n, searchK int, allow func(int32) bool) ([]int32, []float32) {
+	return index.getAllNns(v, n, searchK, allow)
 }
 
 func (index *AnnoyIndex[D]) GetNItems() int32 {
@@ -168,26 +218,49 @@ func (index *AnnoyIndex[D]) GetItem(item int32) []float32 {
 
 func (index *AnnoyIndex[D]) getNode(i int32) *Node {
 	if index.mmap != nil {
-		return GetNodePtr(index.nodes, index.s, i)
+		return GetNodePtr(index.nodes, index.s, index.f, index.distance.ExtraSize(), i)
 	}
-	node, ok := index.cache[i]
-	if ok {
-		return node
+	if cached, ok := index.cache.Load(i); ok {
+		return cached.(*Node)
 	}
-	node = GetNodePtr(index.nodes, index.s, i)
-	index.cache[i] = node
+	node := GetNodePtr(index.nodes, index.s, index.f, index.distance.ExtraSize(), i)
 	if node.V != nil {
 		index.distance.InitNode(node, index.f)
 	}
-	return node
+	actual, _ := index.cache.LoadOrStore(i, node)
+	return actual.(*Node)
+}
+
+// queryScratch holds the per-query working set (hyperplane-side vector node,
+// traversal heap, and dedup set) reused across calls via AnnoyIndex.pool so
+// concurrent/batched queries don't each pay a fresh set of allocations.
+type queryScratch struct {
+	vNode *Node
+	pq    *PriorityQueue
+	nnSet map[int32]struct{}
+}
+
+func (index *AnnoyIndex[D]) acquireScratch() *queryScratch {
+	return index.pool.Get().(*queryScratch)
+}
+
+func (index *AnnoyIndex[D]) releaseScratch(s *queryScratch) {
+	for k := range s.nnSet {
+		delete(s.nnSet, k)
+	}
+	index.pool.Put(s)
 }
 
-func (index *AnnoyIndex[D]) getAllNns(v []float32, n, searchK int) ([]int32, []float32) {
-	vNode := &Node{V: make([]float32, index.f)}
+func (index *AnnoyIndex[D]) getAllNns(v []float32, n, searchK int, allow func(int32) bool) ([]int32, []float32) {
+	scratch := index.acquireScratch()
+	defer index.releaseScratch(scratch)
+
+	vNode := scratch.vNode
 	copy(vNode.V, v)
 	index.distance.InitNode(vNode, index.f)
 
-	pq := &PriorityQueue{}
+	pq := scratch.pq
+	*pq = (*pq)[:0]
 	heap.Init(pq)
 
 	if searchK == -1 {
@@ -198,6 +271,10 @@ func (index *AnnoyIndex[D]) getAllNns(v []float32, n, searchK int) ([]int32, []f
 		heap.Push(pq, &Pair{float32(math.Inf(1)), root})
 	}
 
+	accepted := func(i int32) bool {
+		return allow == nil || allow(i)
+	}
+
 	nns := []int32{}
 	for len(nns) < searchK && pq.Len() > 0 {
 		top := heap.Pop(pq).(*Pair)
@@ -205,9 +282,15 @@ func (index *AnnoyIndex[D]) getAllNns(v []float32, n, searchK int) ([]int32, []f
 		i := top.second
 		nd := index.getNode(i)
 		if nd.Descendants == 1 && i < index.nItems {
-			nns = append(nns, i)
+			if accepted(i) {
+				nns = append(nns, i)
+			}
 		} else if nd.Descendants <= index.k {
-			nns = append(nns, nd.Children[:nd.Descendants]...)
+			for _, c := range nd.Children[:nd.Descendants] {
+				if accepted(c) {
+					nns = append(nns, c)
+				}
+			}
 		} else {
 			margin := index.distance.Margin(nd, v, index.f)
 			heap.Push(pq, &Pair{index.distance.PQDistance(d, margin, 1), nd.Children[1]})
@@ -215,7 +298,7 @@ func (index *AnnoyIndex[D]) getAllNns(v []float32, n, searchK int) ([]int32, []f
 		}
 	}
 
-	nnSet := make(map[int32]struct{})
+	nnSet := scratch.nnSet
 	for _, j := range nns {
 		nnSet[j] = struct{}{}
 	}
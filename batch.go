@@ -0,0 +1,51 @@
+package annoy
+
+import "sync"
+
+// GetNnsByItemBatch runs GetNnsByItem for every item in items concurrently
+// across nWorkers goroutines (nWorkers <= 0 means one goroutine per query),
+// returning results in the same order as items.
+func (index *AnnoyIndex[D]) GetNnsByItemBatch(items []int32, n, searchK, nWorkers int) ([][]int32, [][]float32) {
+	return index.runBatch(len(items), nWorkers, func(i int) ([]int32, []float32) {
+		return index.GetNnsByItem(items[i], n, searchK)
+	})
+}
+
+// GetNnsByVectorBatch runs GetNnsByVector for every vector in vs concurrently
+// across nWorkers goroutines (nWorkers <= 0 means one goroutine per query),
+// returning results in the same order as vs.
+func (index *AnnoyIndex[D]) GetNnsByVectorBatch(vs [][]float32, n, searchK, nWorkers int) ([][]int32, [][]float32) {
+	return index.runBatch(len(vs), nWorkers, func(i int) ([]int32, []float32) {
+		return index.GetNnsByVector(vs[i], n, searchK)
+	})
+}
+
+func (index *AnnoyIndex[D]) runBatch(nQueries, nWorkers int, query func(i int) ([]int32, []float32)) ([][]int32, [][]float32) {
+	results := make([][]int32, nQueries)
+	distances := make([][]float32, nQueries)
+	if nQueries == 0 {
+		return results, distances
+	}
+	if nWorkers <= 0 {
+		nWorkers = nQueries
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < nWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i], distances[i] = query(i)
+			}
+		}()
+	}
+	for i := 0; i < nQueries; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results, distances
+}
@@ -0,0 +1,54 @@
+package annoy
+
+import (
+	"math/rand"
+	"os"
+	"testing"
+)
+
+func TestGetNnsByVectorBatch(t *testing.T) {
+	const f = 8
+	const nItems = 300
+
+	builder := NewAnnoyIndexBuilder[Angular](f)
+	rnd := rand.New(rand.NewSource(7))
+	vectors := make([][]float32, nItems)
+	for i := 0; i < nItems; i++ {
+		v := make([]float32, f)
+		for j := range v {
+			v[j] = rnd.Float32()
+		}
+		vectors[i] = v
+		builder.AddItem(int32(i), v)
+	}
+	if err := builder.Build(4, 2); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	file, err := os.CreateTemp("", "annoy-batch-test-*.ann")
+	if err != nil {
+		t.Fatalf("CreateTemp failed: %v", err)
+	}
+	defer os.Remove(file.Name())
+	file.Close()
+	if err := builder.Save(file.Name()); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	index := NewAnnoyIndex[Angular](f)
+	if err := index.Load(file.Name(), true); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	defer index.Unload()
+
+	results, distances := index.GetNnsByVectorBatch(vectors, 10, -1, 8)
+	if len(results) != nItems || len(distances) != nItems {
+		t.Fatalf("got %d/%d results, want %d", len(results), len(distances), nItems)
+	}
+	for i, r := range results {
+		single, _ := index.GetNnsByVector(vectors[i], 10, -1)
+		if len(r) != len(single) {
+			t.Fatalf("item %d: batch returned %d results, single query returned %d", i, len(r), len(single))
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package annoy
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+)
+
+// AnnoyIndexBuilder constructs an in-memory forest of random-projection trees
+// and serializes it in the same on-disk layout that AnnoyIndex.Load expects,
+// so indexes built here can always be read back by this package. For
+// Angular, Euclidean, and Manhattan the layout also matches Spotify's own
+// Annoy implementation byte-for-byte; DotProduct and Hamming do not (see
+// their doc comments) and only round-trip with this package.
+type AnnoyIndexBuilder[D DistanceMetric] struct {
+	distance D
+	f        int
+	s        int
+	k        int32
+	items    [][]float32
+	nItems   int32
+	nodes    []*Node
+}
+
+func NewAnnoyIndexBuilder[D DistanceMetric](f int) *AnnoyIndexBuilder[D] {
+	b := &AnnoyIndexBuilder[D]{
+		f: f,
+	}
+	b.s = 12 + f*4 + b.distance.ExtraSize()
+	b.k = int32(f + 2)
+	return b
+}
+
+func NewAnnoyIndexBuilderAngular(f int) *AnnoyIndexBuilder[Angular] {
+	return NewAnnoyIndexBuilder[Angular](f)
+}
+
+func NewAnnoyIndexBuilderEuclidean(f int) *AnnoyIndexBuilder[Euclidean] {
+	return NewAnnoyIndexBuilder[Euclidean](f)
+}
+
+func NewAnnoyIndexBuilderManhattan(f int) *AnnoyIndexBuilder[Manhattan] {
+	return NewAnnoyIndexBuilder[Manhattan](f)
+}
+
+func NewAnnoyIndexBuilderDotProduct(f int) *AnnoyIndexBuilder[DotProduct] {
+	return NewAnnoyIndexBuilder[DotProduct](f)
+}
+
+// NewAnnoyIndexBuilderHamming builds a Hamming builder where f is the number
+// of 32-bit words used to pack bits (32*f total bits per item).
+func NewAnnoyIndexBuilderHamming(f int) *AnnoyIndexBuilder[Hamming] {
+	return NewAnnoyIndexBuilder[Hamming](f)
+}
+
+// AddItem stores the vector for item i, growing the item table as needed.
+// Items do not need to be added in order, but every index up to the
+// highest one added must eventually be filled in before Build is called.
+func (b *AnnoyIndexBuilder[D]) AddItem(i int32, v []float32) {
+	for int32(len(b.items)) <= i {
+		b.items = append(b.items, nil)
+	}
+	vec := make([]float32, b.f)
+	copy(vec, v)
+	b.items[i] = vec
+	if i+1 > b.nItems {
+		b.nItems = i + 1
+	}
+}
+
+// Build constructs nTrees random-projection trees, splitting each internal
+// node with the configured metric's CreateSplit (two random pivot items and
+// their separating hyperplane, or a random bit for Hamming), recursing until
+// a node has at most
+// k descendants. Trees are built in parallel across nJobs workers; if nJobs
+// is <= 0, one worker per tree is used.
+func (b *AnnoyIndexBuilder[D]) Build(nTrees int, nJobs int) error {
+	for i, v := range b.items {
+		if v == nil {
+			return fmt.Errorf("item %d was never added", i)
+		}
+	}
+
+	leaves := make([]*Node, b.nItems)
+	for i := int32(0); i < b.nItems; i++ {
+		leaf := &Node{Descendants: 1, Children: make([]int32, 2), V: make([]float32, b.f)}
+		copy(leaf.V, b.items[i])
+		b.distance.InitNode(leaf, b.f)
+		leaves[i] = leaf
+	}
+	b.nodes = leaves
+
+	if nJobs <= 0 {
+		nJobs = nTrees
+	}
+	sem := make(chan struct{}, nJobs)
+	localNodes := make([][]*Node, nTrees)
+	localRoots := make([]int32, nTrees)
+	var wg sync.WaitGroup
+	for t := 0; t < nTrees; t++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(t int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			rnd := rand.New(rand.NewSource(time.Now().UnixNano() + int64(t)))
+			all := make([]int32, b.nItems)
+			for i := range all {
+				all[i] = int32(i)
+			}
+			local, root := b.buildTree(all, rnd)
+			localNodes[t] = local
+			localRoots[t] = root
+		}(t)
+	}
+	wg.Wait()
+
+	roots := make([]int32, nTrees)
+	for t, local := range localNodes {
+		offset := int32(len(b.nodes))
+		for _, n := range local {
+			for i, c := range n.Children {
+				if c >= b.nItems {
+					n.Children[i] = c - b.nItems + offset
+				}
+			}
+		}
+		b.nodes = append(b.nodes, local...)
+
+		root := localRoots[t]
+		if root >= b.nItems {
+			root = root - b.nItems + offset
+		}
+		roots[t] = root
+	}
+
+	// Mirror Spotify Annoy's on-disk layout: append a copy of each tree's
+	// root after all tree bodies, so Load can find the roots by scanning
+	// from the tail for a run of nodes that all have nItems descendants.
+	for _, root := range roots {
+		copyNode := *b.nodes[root]
+		b.nodes = append(b.nodes, &copyNode)
+	}
+	return nil
+}
+
+// buildTree recursively partitions indices into a random-projection tree.
+// Internal node references into the returned slice are encoded relative to
+// b.nItems (i.e. a child value >= b.nItems refers to local[child-b.nItems]);
+// values below b.nItems are raw item ids and pass straight through to the
+// final file, since items occupy nodes [0, nItems) in the merged index.
+func (b *AnnoyIndexBuilder[D]) buildTree(indices []int32, rnd *rand.Rand) ([]*Node, int32) {
+	if len(indices) == 1 {
+		return nil, indices[0]
+	}
+
+	if int32(len(indices)) <= b.k {
+		n := &Node{Descendants: int32(len(indices)), Children: make([]int32, int(b.k))}
+		copy(n.Children, indices)
+		return []*Node{n}, b.nItems
+	}
+
+	var left, right []int32
+	var split *Node
+	for attempt := 0; attempt < 3; attempt++ {
+		split = b.distance.CreateSplit(b.itemNodes(indices), b.f, rnd)
+		left, right = left[:0], right[:0]
+		for _, i := range indices {
+			if b.distance.Margin(split, b.nodeVector(i), b.f) > 0 {
+				right = append(right, i)
+			} else {
+				left = append(left, i)
+			}
+		}
+		if len(left) > 0 && len(right) > 0 {
+			break
+		}
+	}
+	if len(left) == 0 || len(right) == 0 {
+		mid := len(indices) / 2
+		left = append(left[:0], indices[:mid]...)
+		right = append(right[:0], indices[mid:]...)
+	}
+
+	if split.Children == nil {
+		split.Children = make([]int32, 2)
+	}
+
+	leftNodes, leftRoot := b.buildTree(left, rnd)
+	rightNodes, rightRoot := b.buildTree(right, rnd)
+
+	// Local indices produced by the right subtree must shift past the left
+	// subtree's nodes once the two are merged into one slice.
+	shift := int32(len(leftNodes))
+	for _, n := range rightNodes {
+		for i, c := range n.Children {
+			if c >= b.nItems {
+				n.Children[i] = c + shift
+			}
+		}
+	}
+	if rightRoot >= b.nItems {
+		rightRoot += shift
+	}
+
+	all := append(leftNodes, rightNodes...)
+	split.Descendants = int32(len(indices))
+	split.Children[0] = leftRoot
+	split.Children[1] = rightRoot
+	all = append(all, split)
+	return all, b.nItems + int32(len(all)) - 1
+}
+
+func (b *AnnoyIndexBuilder[D]) itemNodes(indices []int32) []*Node {
+	nodes := make([]*Node, len(indices))
+	for i, idx := range indices {
+		nodes[i] = b.nodes[idx]
+	}
+	return nodes
+}
+
+func (b *AnnoyIndexBuilder[D]) nodeVector(i int32) []float32 {
+	return b.nodes[i].V
+}
+
+// Save writes the built forest to filename in AnnoyIndex's on-disk format:
+// each node is a fixed-size record of Descendants (int32), followed for
+// metrics with ExtraSize()>0 (Euclidean, Manhattan) by the split offset `a`,
+// then either k child ids (a leaf group) or two child ids and f
+// hyperplane/vector floats. For Euclidean and Manhattan this mirrors
+// Spotify Annoy's Minkowski node layout, where `a` sits right after
+// n_descendants rather than after v, so files round-trip with the
+// C++/Python implementation; see DotProduct and Hamming's doc comments for
+// the metrics where that isn't the case. Each tree's root is duplicated at
+// the tail of the file so Load can find all of them by scanning backwards
+// for a contiguous run of nodes with Descendants==nItems.
+func (b *AnnoyIndexBuilder[D]) Save(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("unable to create: %v", err)
+	}
+	defer f.Close()
+
+	extra := b.distance.ExtraSize()
+	childOff := 4
+	if extra > 0 {
+		childOff = 8
+	}
+
+	w := bufio.NewWriter(f)
+	buf := make([]byte, b.s)
+	for _, n := range b.nodes {
+		for i := range buf {
+			buf[i] = 0
+		}
+		binary.LittleEndian.PutUint32(buf[0:4], uint32(n.Descendants))
+		if n.Descendants > 2 && n.Descendants <= b.k {
+			for i := 0; i < int(b.k); i++ {
+				var c int32
+				if i < len(n.Children) {
+					c = n.Children[i]
+				}
+				binary.LittleEndian.PutUint32(buf[childOff+4*i:childOff+4+4*i], uint32(c))
+			}
+		} else {
+			binary.LittleEndian.PutUint32(buf[childOff:childOff+4], uint32(n.Children[0]))
+			binary.LittleEndian.PutUint32(buf[childOff+4:childOff+8], uint32(n.Children[1]))
+			vOff := childOff + 8
+			for i := 0; i < b.f && i < len(n.V); i++ {
+				binary.LittleEndian.PutUint32(buf[vOff+4*i:vOff+4+4*i], math.Float32bits(n.V[i]))
+			}
+			if extra > 0 {
+				binary.LittleEndian.PutUint32(buf[4:8], math.Float32bits(n.A))
+			}
+		}
+		if _, err := w.Write(buf); err != nil {
+			return fmt.Errorf("unable to write: %v", err)
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,69 @@
+package annoy
+
+import (
+	"math/rand"
+	"os"
+	"testing"
+)
+
+func TestBuilderBuildAndQuery(t *testing.T) {
+	const f = 8
+	const nItems = 200
+
+	builder := NewAnnoyIndexBuilder[Angular](f)
+	rnd := rand.New(rand.NewSource(1))
+	vectors := make([][]float32, nItems)
+	for i := 0; i < nItems; i++ {
+		v := make([]float32, f)
+		for j := range v {
+			v[j] = rnd.Float32()
+		}
+		vectors[i] = v
+		builder.AddItem(int32(i), v)
+	}
+
+	if err := builder.Build(4, 2); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	file, err := os.CreateTemp("", "annoy-builder-test-*.ann")
+	if err != nil {
+		t.Fatalf("CreateTemp failed: %v", err)
+	}
+	defer os.Remove(file.Name())
+	file.Close()
+
+	if err := builder.Save(file.Name()); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	index := NewAnnoyIndex[Angular](f)
+	if err := index.Load(file.Name(), true); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	defer index.Unload()
+
+	if got := index.GetNItems(); got != nItems {
+		t.Fatalf("GetNItems() = %d, want %d", got, nItems)
+	}
+	if got := index.GetNTrees(); got != 4 {
+		t.Fatalf("GetNTrees() = %d, want 4", got)
+	}
+
+	result, dists := index.GetNnsByVector(vectors[0], 10, -1)
+	if len(result) == 0 {
+		t.Fatal("GetNnsByVector returned no results")
+	}
+	if len(result) != len(dists) {
+		t.Fatalf("result/dist length mismatch: %d vs %d", len(result), len(dists))
+	}
+	found := false
+	for _, r := range result {
+		if r == 0 {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("nearest neighbors of item 0 did not include item 0 itself")
+	}
+}
@@ -0,0 +1,94 @@
+package annoy
+
+import (
+	"math"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+func buildAndQuery[D DistanceMetric](t *testing.T, builder *AnnoyIndexBuilder[D], index *AnnoyIndex[D], vectors [][]float32) {
+	t.Helper()
+	for i, v := range vectors {
+		builder.AddItem(int32(i), v)
+	}
+	if err := builder.Build(3, 2); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	file, err := os.CreateTemp("", "annoy-distance-test-*.ann")
+	if err != nil {
+		t.Fatalf("CreateTemp failed: %v", err)
+	}
+	defer os.Remove(file.Name())
+	file.Close()
+	if err := builder.Save(file.Name()); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if err := index.Load(file.Name(), true); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	defer index.Unload()
+
+	result, _ := index.GetNnsByVector(vectors[0], 5, -1)
+	if len(result) == 0 {
+		t.Fatal("GetNnsByVector returned no results")
+	}
+}
+
+func TestEuclideanBuildAndQuery(t *testing.T) {
+	const f = 8
+	rnd := rand.New(rand.NewSource(3))
+	vectors := make([][]float32, 150)
+	for i := range vectors {
+		v := make([]float32, f)
+		for j := range v {
+			v[j] = rnd.Float32() * 10
+		}
+		vectors[i] = v
+	}
+	buildAndQuery(t, NewAnnoyIndexBuilder[Euclidean](f), NewAnnoyIndex[Euclidean](f), vectors)
+}
+
+func TestManhattanBuildAndQuery(t *testing.T) {
+	const f = 8
+	rnd := rand.New(rand.NewSource(4))
+	vectors := make([][]float32, 150)
+	for i := range vectors {
+		v := make([]float32, f)
+		for j := range v {
+			v[j] = rnd.Float32() * 10
+		}
+		vectors[i] = v
+	}
+	buildAndQuery(t, NewAnnoyIndexBuilder[Manhattan](f), NewAnnoyIndex[Manhattan](f), vectors)
+}
+
+func TestDotProductBuildAndQuery(t *testing.T) {
+	const f = 8
+	rnd := rand.New(rand.NewSource(5))
+	vectors := make([][]float32, 150)
+	for i := range vectors {
+		v := make([]float32, f)
+		for j := range v {
+			v[j] = rnd.Float32()
+		}
+		vectors[i] = v
+	}
+	buildAndQuery(t, NewAnnoyIndexBuilder[DotProduct](f), NewAnnoyIndex[DotProduct](f), vectors)
+}
+
+func TestHammingBuildAndQuery(t *testing.T) {
+	const words = 2 // 64 bits per item
+	rnd := rand.New(rand.NewSource(6))
+	vectors := make([][]float32, 150)
+	for i := range vectors {
+		v := make([]float32, words)
+		for j := range v {
+			v[j] = math.Float32frombits(rnd.Uint32())
+		}
+		vectors[i] = v
+	}
+	buildAndQuery(t, NewAnnoyIndexBuilder[Hamming](words), NewAnnoyIndex[Hamming](words), vectors)
+}
@@ -0,0 +1,52 @@
+//go:build amd64
+
+package annoy
+
+import "golang.org/x/sys/cpu"
+
+// dotAVX2 sums x[i]*y[i] for i in [0,n) 8 floats at a time. n must be a
+// multiple of 8.
+//
+//go:noescape
+func dotAVX2(x, y *float32, n int) float32
+
+// dotAVX512 sums x[i]*y[i] for i in [0,n) 16 floats at a time. n must be a
+// multiple of 16.
+//
+//go:noescape
+func dotAVX512(x, y *float32, n int) float32
+
+func selectDot() func(x, y []float32, f int) float32 {
+	switch {
+	case cpu.X86.HasAVX512F:
+		return dotAVX512Dot
+	case cpu.X86.HasAVX2:
+		return dotAVX2Dot
+	default:
+		return dotGeneric
+	}
+}
+
+func dotAVX2Dot(x, y []float32, f int) float32 {
+	n := f - f%8
+	var sum float32
+	if n > 0 {
+		sum = dotAVX2(&x[0], &y[0], n)
+	}
+	for z := n; z < f; z++ {
+		sum += x[z] * y[z]
+	}
+	return sum
+}
+
+func dotAVX512Dot(x, y []float32, f int) float32 {
+	n := f - f%16
+	var sum float32
+	if n > 0 {
+		sum = dotAVX512(&x[0], &y[0], n)
+	}
+	for z := n; z < f; z++ {
+		sum += x[z] * y[z]
+	}
+	return sum
+}
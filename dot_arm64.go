@@ -0,0 +1,30 @@
+//go:build arm64
+
+package annoy
+
+import "golang.org/x/sys/cpu"
+
+// dotNEON sums x[i]*y[i] for i in [0,n) 4 floats at a time. n must be a
+// multiple of 4.
+//
+//go:noescape
+func dotNEON(x, y *float32, n int) float32
+
+func selectDot() func(x, y []float32, f int) float32 {
+	if cpu.ARM64.HasASIMD {
+		return dotNEONDot
+	}
+	return dotGeneric
+}
+
+func dotNEONDot(x, y []float32, f int) float32 {
+	n := f - f%4
+	var sum float32
+	if n > 0 {
+		sum = dotNEON(&x[0], &y[0], n)
+	}
+	for z := n; z < f; z++ {
+		sum += x[z] * y[z]
+	}
+	return sum
+}
@@ -0,0 +1,7 @@
+//go:build !amd64 && !arm64
+
+package annoy
+
+func selectDot() func(x, y []float32, f int) float32 {
+	return dotGeneric
+}
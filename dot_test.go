@@ -0,0 +1,70 @@
+package annoy
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func randVec(rnd *rand.Rand, f int) []float32 {
+	v := make([]float32, f)
+	for i := range v {
+		v[i] = rnd.Float32()*2 - 1
+	}
+	return v
+}
+
+func TestDotMatchesGeneric(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+	for _, f := range []int{0, 1, 7, 8, 9, 64, 128, 512, 1536} {
+		x := randVec(rnd, f)
+		y := randVec(rnd, f)
+		want := dotGeneric(x, y, f)
+		got := Dot(x, y, f)
+		if diff := want - got; diff > 1e-3 || diff < -1e-3 {
+			t.Errorf("f=%d: Dot=%v, want dotGeneric=%v", f, got, want)
+		}
+	}
+}
+
+func BenchmarkDot(b *testing.B) {
+	for _, f := range []int{64, 128, 512, 1536} {
+		rnd := rand.New(rand.NewSource(1))
+		x := randVec(rnd, f)
+		y := randVec(rnd, f)
+		b.Run(benchName(f), func(b *testing.B) {
+			b.SetBytes(int64(f * 4 * 2))
+			for i := 0; i < b.N; i++ {
+				Dot(x, y, f)
+			}
+		})
+	}
+}
+
+func BenchmarkDotGeneric(b *testing.B) {
+	for _, f := range []int{64, 128, 512, 1536} {
+		rnd := rand.New(rand.NewSource(1))
+		x := randVec(rnd, f)
+		y := randVec(rnd, f)
+		b.Run(benchName(f), func(b *testing.B) {
+			b.SetBytes(int64(f * 4 * 2))
+			for i := 0; i < b.N; i++ {
+				dotGeneric(x, y, f)
+			}
+		})
+	}
+}
+
+func benchName(f int) string {
+	switch f {
+	case 64:
+		return "f=64"
+	case 128:
+		return "f=128"
+	case 512:
+		return "f=512"
+	case 1536:
+		return "f=1536"
+	default:
+		return "f=other"
+	}
+}
@@ -0,0 +1,58 @@
+package annoy
+
+import (
+	"math/rand"
+	"os"
+	"testing"
+)
+
+func TestGetNnsByVectorFiltered(t *testing.T) {
+	const f = 8
+	const nItems = 200
+
+	builder := NewAnnoyIndexBuilder[Angular](f)
+	rnd := rand.New(rand.NewSource(2))
+	vectors := make([][]float32, nItems)
+	for i := 0; i < nItems; i++ {
+		v := make([]float32, f)
+		for j := range v {
+			v[j] = rnd.Float32()
+		}
+		vectors[i] = v
+		builder.AddItem(int32(i), v)
+	}
+	if err := builder.Build(4, 2); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	file, err := os.CreateTemp("", "annoy-filter-test-*.ann")
+	if err != nil {
+		t.Fatalf("CreateTemp failed: %v", err)
+	}
+	defer os.Remove(file.Name())
+	file.Close()
+	if err := builder.Save(file.Name()); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	index := NewAnnoyIndex[Angular](f)
+	if err := index.Load(file.Name(), true); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	defer index.Unload()
+
+	allow := NewAllowSet(nItems)
+	for i := int32(0); i < nItems; i += 2 {
+		allow.Add(i)
+	}
+
+	result, _ := index.GetNnsByVectorFiltered(vectors[0], 20, -1, allow.Func())
+	if len(result) == 0 {
+		t.Fatal("GetNnsByVectorFiltered returned no results")
+	}
+	for _, r := range result {
+		if r%2 != 0 {
+			t.Fatalf("result %d violates filter (expected only even ids)", r)
+		}
+	}
+}
@@ -42,29 +42,53 @@ func (pq *PriorityQueue) Top() *Pair {
 	return (*pq)[0]
 }
 
-func GetNodePtr(nodes []byte, size int, i int32) *Node {
-	arrSize := (size - 12) / 4
+// GetNodePtr decodes the node at index i from a record of size bytes. For
+// metrics with extra>0 (Euclidean, Manhattan), this follows Spotify Annoy's
+// Minkowski node layout: Descendants, then the split offset `a`, then
+// children/vector. Metrics with extra==0 pack children/vector right after
+// Descendants, with no `a` field.
+func GetNodePtr(nodes []byte, size int, f int, extra int, i int32) *Node {
+	arrSize := f
+	k := arrSize + 2
 	node := Node{}
-	node.Descendants = int32(binary.LittleEndian.Uint32(nodes[size*int(i) : size*int(i)+4]))
-	if node.Descendants > 2 && node.Descendants <= int32(arrSize)+2 {
-		node.Children = make([]int32, arrSize+2)
-		for j := 0; j < arrSize+2; j++ {
-			node.Children[j] = int32(binary.LittleEndian.Uint32(nodes[size*int(i)+4+4*j : size*int(i)+8+4*j]))
+	base := size * int(i)
+	node.Descendants = int32(binary.LittleEndian.Uint32(nodes[base : base+4]))
+
+	childOff := 4
+	if extra > 0 {
+		node.A = math.Float32frombits(binary.LittleEndian.Uint32(nodes[base+4 : base+8]))
+		childOff = 8
+	}
+
+	if node.Descendants > 2 && node.Descendants <= int32(k) {
+		node.Children = make([]int32, k)
+		for j := 0; j < k; j++ {
+			node.Children[j] = int32(binary.LittleEndian.Uint32(nodes[base+childOff+4*j : base+childOff+4+4*j]))
 		}
 	} else {
 		node.Children = make([]int32, 2)
 		for j := 0; j < 2; j++ {
-			node.Children[j] = int32(binary.LittleEndian.Uint32(nodes[size*int(i)+4+4*j : size*int(i)+8+4*j]))
+			node.Children[j] = int32(binary.LittleEndian.Uint32(nodes[base+childOff+4*j : base+childOff+4+4*j]))
 		}
+		vOff := base + childOff + 8
 		node.V = make([]float32, arrSize)
 		for j := 0; j < arrSize; j++ {
-			node.V[j] = math.Float32frombits(binary.LittleEndian.Uint32(nodes[size*int(i)+12+4*j : size*int(i)+16+4*j]))
+			node.V[j] = math.Float32frombits(binary.LittleEndian.Uint32(nodes[vOff+4*j : vOff+4+4*j]))
 		}
 	}
 	return &node
 }
 
+// dotFn is the active dot-product implementation, chosen at package init
+// time by selectDot based on the detected CPU features (see dot_amd64.go /
+// dot_arm64.go); platforms without a SIMD kernel fall back to dotGeneric.
+var dotFn = selectDot()
+
 func Dot(x, y []float32, f int) float32 {
+	return dotFn(x, y, f)
+}
+
+func dotGeneric(x, y []float32, f int) float32 {
 	var s float32
 	for z := 0; z < f; z++ {
 		s += x[z] * y[z]
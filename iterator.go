@@ -0,0 +1,156 @@
+package annoy
+
+import (
+	"container/heap"
+	"math"
+)
+
+// iterEntry is either an unresolved split/leaf-group node (priority is the
+// same PQDistance bound getAllNns traverses with) or a resolved item whose
+// real distance to the query vector has already been computed (priority is
+// the negated distance, so it sorts on the same "larger pops first" scale
+// as unresolved bounds). Resolving items as soon as they're discovered,
+// rather than buffering them unsorted, approximates nearest-first order:
+// it is exact when the metric's Margin is a true lower bound on distance
+// (Euclidean, Manhattan), but only approximate otherwise (e.g. Angular),
+// since a resolved item's real distance and an unresolved node's bound
+// priority are not guaranteed to be on a directly comparable scale.
+type iterEntry struct {
+	priority float32
+	id       int32
+	resolved bool
+}
+
+type iterHeap []*iterEntry
+
+func (h iterHeap) Len() int           { return len(h) }
+func (h iterHeap) Less(i, j int) bool { return h[i].priority > h[j].priority }
+func (h iterHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *iterHeap) Push(x any)        { *h = append(*h, x.(*iterEntry)) }
+func (h *iterHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// SearchIterator lazily descends an AnnoyIndex's trees, yielding items one
+// at a time instead of materializing a fixed top-N result set, in
+// approximately nearest-first order (exact for metrics whose Margin is a
+// true distance lower bound, such as Euclidean and Manhattan; approximate
+// for others, such as Angular). This lets callers stop as soon as they've
+// seen enough — e.g. after applying an external filter — without having
+// to guess n up front; callers that need every in-range item regardless
+// of yield order should drain fully, as SearchRange does, rather than
+// stopping at the first out-of-range distance.
+type SearchIterator[D DistanceMetric] struct {
+	index   *AnnoyIndex[D]
+	vNode   *Node
+	v       []float32
+	heap    *iterHeap
+	visited map[int32]struct{}
+	seen    int
+	searchK int
+}
+
+// Search starts a lazy nearest-neighbor traversal towards v. searchK bounds
+// how many tree candidates the traversal will expand before Next stops
+// discovering new ones (already-resolved candidates are still drained
+// after that point), with the same meaning as the searchK argument to
+// GetNnsByVector (-1 picks a small default of one expansion per tree).
+func (index *AnnoyIndex[D]) Search(v []float32, searchK int) *SearchIterator[D] {
+	vNode := &Node{V: make([]float32, index.f)}
+	copy(vNode.V, v)
+	index.distance.InitNode(vNode, index.f)
+
+	h := &iterHeap{}
+	heap.Init(h)
+	if searchK == -1 {
+		searchK = len(index.roots)
+	}
+	for _, root := range index.roots {
+		heap.Push(h, &iterEntry{priority: float32(math.Inf(1)), id: root})
+	}
+
+	return &SearchIterator[D]{
+		index:   index,
+		vNode:   vNode,
+		v:       v,
+		heap:    h,
+		visited: make(map[int32]struct{}),
+		searchK: searchK,
+	}
+}
+
+// resolve computes id's real distance to the query vector and pushes it
+// onto the heap so it competes with still-unresolved node bounds on equal
+// footing instead of being emitted in discovery order.
+func (it *SearchIterator[D]) resolve(id int32) {
+	index := it.index
+	dist := index.distance.NormalizeDistance(index.distance.Distance(it.vNode, index.getNode(id), index.f))
+	heap.Push(it.heap, &iterEntry{priority: -dist, id: id, resolved: true})
+	it.seen++
+}
+
+// Next returns the next nearest item not yet returned by this iterator. ok
+// is false once every resolved candidate has been drained and either the
+// heap is empty or the traversal budget (searchK) has stopped producing
+// new ones.
+func (it *SearchIterator[D]) Next() (id int32, dist float32, ok bool) {
+	index := it.index
+	for it.heap.Len() > 0 {
+		top := heap.Pop(it.heap).(*iterEntry)
+		if top.resolved {
+			if _, dup := it.visited[top.id]; dup {
+				continue
+			}
+			it.visited[top.id] = struct{}{}
+			return top.id, -top.priority, true
+		}
+
+		if it.seen >= it.searchK {
+			continue
+		}
+
+		d := top.priority
+		i := top.id
+		nd := index.getNode(i)
+		if nd.Descendants == 1 && i < index.nItems {
+			it.resolve(i)
+		} else if nd.Descendants <= index.k {
+			for _, c := range nd.Children[:nd.Descendants] {
+				it.resolve(c)
+			}
+		} else {
+			margin := index.distance.Margin(nd, it.v, index.f)
+			heap.Push(it.heap, &iterEntry{priority: index.distance.PQDistance(d, margin, 1), id: nd.Children[1]})
+			heap.Push(it.heap, &iterEntry{priority: index.distance.PQDistance(d, margin, 0), id: nd.Children[0]})
+		}
+	}
+	return 0, 0, false
+}
+
+// SearchRange returns every item within maxDist of v, expanding the same
+// lazy traversal as Search/Next up to searchK candidates rather than
+// truncating to a fixed top-N. Next's yield order is only approximately
+// nearest-first for metrics whose Margin isn't a true distance bound (see
+// SearchIterator), so this drains the traversal fully and filters by
+// radius rather than stopping at the first out-of-range distance, which
+// would otherwise miss any nearer-but-later-resolved candidates.
+func (index *AnnoyIndex[D]) SearchRange(v []float32, maxDist float32, searchK int) ([]int32, []float32) {
+	it := index.Search(v, searchK)
+	ids := []int32{}
+	dists := []float32{}
+	for {
+		id, dist, ok := it.Next()
+		if !ok {
+			break
+		}
+		if dist <= maxDist {
+			ids = append(ids, id)
+			dists = append(dists, dist)
+		}
+	}
+	return ids, dists
+}
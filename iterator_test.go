@@ -0,0 +1,143 @@
+package annoy
+
+import (
+	"math/rand"
+	"os"
+	"testing"
+)
+
+// TestSearchIteratorNearestFirst checks the nearest-first guarantee for a
+// metric whose Margin is a true distance lower bound, where the guarantee
+// is exact. Angular's Margin isn't a true bound, so its iterator order is
+// only approximate; that case is covered by
+// TestSearchRangeDrainsPastOutOfRangeResults below instead of an ordering
+// assertion.
+func TestSearchIteratorNearestFirst(t *testing.T) {
+	const f = 8
+	const nItems = 200
+
+	builder := NewAnnoyIndexBuilder[Euclidean](f)
+	rnd := rand.New(rand.NewSource(8))
+	vectors := make([][]float32, nItems)
+	for i := 0; i < nItems; i++ {
+		v := make([]float32, f)
+		for j := range v {
+			v[j] = rnd.Float32()
+		}
+		vectors[i] = v
+		builder.AddItem(int32(i), v)
+	}
+	if err := builder.Build(4, 2); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	file, err := os.CreateTemp("", "annoy-iterator-test-*.ann")
+	if err != nil {
+		t.Fatalf("CreateTemp failed: %v", err)
+	}
+	defer os.Remove(file.Name())
+	file.Close()
+	if err := builder.Save(file.Name()); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	index := NewAnnoyIndex[Euclidean](f)
+	if err := index.Load(file.Name(), true); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	defer index.Unload()
+
+	it := index.Search(vectors[0], nItems)
+	seen := make(map[int32]struct{})
+	count := 0
+	lastDist := float32(-1)
+	for {
+		id, dist, ok := it.Next()
+		if !ok {
+			break
+		}
+		if _, dup := seen[id]; dup {
+			t.Fatalf("item %d returned twice by iterator", id)
+		}
+		seen[id] = struct{}{}
+		if dist < lastDist {
+			t.Fatalf("Next returned distance %f after %f: results must be nearest-first", dist, lastDist)
+		}
+		lastDist = dist
+		count++
+		if count >= 20 {
+			break
+		}
+	}
+	if count == 0 {
+		t.Fatal("iterator returned no results")
+	}
+}
+
+// TestSearchRangeDrainsPastOutOfRangeResults builds an Angular index, where
+// the iterator's yield order is only approximate, and checks that
+// SearchRange still returns every in-range neighbor instead of stopping at
+// the first out-of-range distance it happens to see.
+func TestSearchRangeDrainsPastOutOfRangeResults(t *testing.T) {
+	const f = 8
+	const nItems = 200
+
+	builder := NewAnnoyIndexBuilder[Angular](f)
+	rnd := rand.New(rand.NewSource(8))
+	vectors := make([][]float32, nItems)
+	for i := 0; i < nItems; i++ {
+		v := make([]float32, f)
+		for j := range v {
+			v[j] = rnd.Float32()
+		}
+		vectors[i] = v
+		builder.AddItem(int32(i), v)
+	}
+	if err := builder.Build(4, 2); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	file, err := os.CreateTemp("", "annoy-iterator-test-*.ann")
+	if err != nil {
+		t.Fatalf("CreateTemp failed: %v", err)
+	}
+	defer os.Remove(file.Name())
+	file.Close()
+	if err := builder.Save(file.Name()); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	index := NewAnnoyIndex[Angular](f)
+	if err := index.Load(file.Name(), true); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	defer index.Unload()
+
+	const maxDist = float32(0.6)
+	ids, dists := index.SearchRange(vectors[0], maxDist, nItems)
+	if len(ids) != len(dists) {
+		t.Fatalf("id/dist length mismatch: %d vs %d", len(ids), len(dists))
+	}
+	for _, d := range dists {
+		if d > maxDist {
+			t.Fatalf("SearchRange returned distance %f over maxDist", d)
+		}
+	}
+
+	// Simulate the earlier (buggy) behavior of stopping at the first
+	// out-of-range item, since Next's yield order is only approximate for
+	// Angular: a correct drain-and-filter must never find fewer in-range
+	// neighbors than that early-break approach would have.
+	stopped := 0
+	itEarly := index.Search(vectors[0], nItems)
+	for {
+		_, dist, ok := itEarly.Next()
+		if !ok || dist > maxDist {
+			break
+		}
+		stopped++
+	}
+	if len(ids) < stopped {
+		t.Fatalf("SearchRange found %d in-range neighbors, fewer than %d found by stopping at the first out-of-range item", len(ids), stopped)
+	}
+}